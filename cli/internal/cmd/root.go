@@ -0,0 +1,22 @@
+// Package cmd assembles the top-level `turbo` command tree out of the
+// CommandFactory sets each subsystem exposes, the way cmd/turbo/main.go's
+// mitchellh/cli.CLI expects them.
+package cmd
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/scope"
+)
+
+// Commands returns the full set of CommandFactory entries for the `turbo`
+// binary, merging in each subsystem's commands. Add new subsystems here as
+// they're built rather than leaving their commands unregistered.
+func Commands(ui cli.Ui, logger hclog.Logger, repoRoot string, scopeOpts *scope.Opts, packageInfos map[interface{}]*fs.PackageJSON) map[string]cli.CommandFactory {
+	commands := map[string]cli.CommandFactory{}
+	for name, factory := range scope.BaselineCommands(ui, logger, repoRoot, scopeOpts, packageInfos) {
+		commands[name] = factory
+	}
+	return commands
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/vercel/turborepo/cli/internal/scope"
+)
+
+func TestCommands_RegistersBaselineSubcommands(t *testing.T) {
+	commands := Commands(&cli.BasicUi{}, nil, "/repo", &scope.Opts{}, nil)
+	for _, key := range []string{"scope baseline write", "scope baseline read"} {
+		if _, ok := commands[key]; !ok {
+			t.Fatalf("expected %q to be registered in the command tree", key)
+		}
+	}
+}
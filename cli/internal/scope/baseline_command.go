@@ -0,0 +1,120 @@
+package scope
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// BaselineWriteCommand implements `turbo scope baseline write`: it computes
+// the current content hash of every workspace package and writes it out as a
+// baseline manifest for later `--since-hash` comparisons.
+type BaselineWriteCommand struct {
+	UI       cli.Ui
+	Logger   hclog.Logger
+	RepoRoot string
+	Opts     *Opts
+
+	// PackageInfos is supplied by the caller that builds the package graph
+	// (see context.Context.PackageInfos).
+	PackageInfos map[interface{}]*fs.PackageJSON
+}
+
+// Synopsis of this command
+func (c *BaselineWriteCommand) Synopsis() string {
+	return "Writes a content-hash baseline manifest for --since-hash"
+}
+
+// Help returns information about the `turbo scope baseline write` command
+func (c *BaselineWriteCommand) Help() string {
+	return `
+Usage: turbo scope baseline write [path]
+
+    Computes a content hash for every workspace package and writes the
+    result to [path] (default: .turbo/baseline.json). Later invocations of
+    "turbo run --since-hash=[path]" report a package as changed whenever its
+    current content hash differs from what's recorded here.
+`
+}
+
+// Run writes the baseline manifest
+func (c *BaselineWriteCommand) Run(args []string) int {
+	path := DefaultBaselinePath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	manifest, err := ComputeBaselineManifest(c.Opts, c.RepoRoot, c.PackageInfos)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("failed to compute baseline manifest: %v", err))
+		return 1
+	}
+	if err := WriteBaselineManifest(path, manifest); err != nil {
+		c.UI.Error(fmt.Sprintf("failed to write baseline manifest: %v", err))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Wrote baseline manifest for %v packages to %v", len(manifest.Packages), path))
+	return 0
+}
+
+// BaselineReadCommand implements `turbo scope baseline read`: it prints out
+// the contents of a previously-written baseline manifest.
+type BaselineReadCommand struct {
+	UI cli.Ui
+}
+
+// Synopsis of this command
+func (c *BaselineReadCommand) Synopsis() string {
+	return "Prints a content-hash baseline manifest"
+}
+
+// Help returns information about the `turbo scope baseline read` command
+func (c *BaselineReadCommand) Help() string {
+	return `
+Usage: turbo scope baseline read [path]
+
+    Prints the package -> content hash mapping stored in [path]
+    (default: .turbo/baseline.json).
+`
+}
+
+// Run reads and prints the baseline manifest
+func (c *BaselineReadCommand) Run(args []string) int {
+	path := DefaultBaselinePath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	manifest, err := ReadBaselineManifest(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("failed to read baseline manifest: %v", err))
+		return 1
+	}
+	for pkgName, hash := range manifest.Packages {
+		c.UI.Output(fmt.Sprintf("%v %v", hash, pkgName))
+	}
+	return 0
+}
+
+// BaselineCommands returns the mitchellh/cli command factories for
+// "scope baseline write" and "scope baseline read", keyed the way the
+// top-level Commands map (see internal/cmd.Commands, which merges this in)
+// keys its nested subcommands: a space-separated path.
+func BaselineCommands(ui cli.Ui, logger hclog.Logger, repoRoot string, opts *Opts, packageInfos map[interface{}]*fs.PackageJSON) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
+		"scope baseline write": func() (cli.Command, error) {
+			return &BaselineWriteCommand{
+				UI:           ui,
+				Logger:       logger,
+				RepoRoot:     repoRoot,
+				Opts:         opts,
+				PackageInfos: packageInfos,
+			}, nil
+		},
+		"scope baseline read": func() (cli.Command, error) {
+			return &BaselineReadCommand{
+				UI: ui,
+			}, nil
+		},
+	}
+}
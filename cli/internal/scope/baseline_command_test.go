@@ -0,0 +1,45 @@
+package scope
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestBaselineCommands_RegistersExpectedKeys(t *testing.T) {
+	commands := BaselineCommands(&cli.BasicUi{}, nil, "/repo", &Opts{}, nil)
+	for _, key := range []string{"scope baseline write", "scope baseline read"} {
+		factory, ok := commands[key]
+		if !ok {
+			t.Fatalf("expected %q to be registered, got keys %v", key, commandKeys(commands))
+		}
+		if _, err := factory(); err != nil {
+			t.Errorf("factory for %q returned an error: %v", key, err)
+		}
+	}
+}
+
+func commandKeys(commands map[string]cli.CommandFactory) []string {
+	keys := make([]string, 0, len(commands))
+	for key := range commands {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestReadBaselineManifest_ReadsWhatWriteWrote(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	manifest := &BaselineManifest{Packages: map[string]string{"pkg-a": "deadbeef"}}
+	if err := WriteBaselineManifest(path, manifest); err != nil {
+		t.Fatalf("WriteBaselineManifest() error: %v", err)
+	}
+
+	got, err := ReadBaselineManifest(path)
+	if err != nil {
+		t.Fatalf("ReadBaselineManifest() error: %v", err)
+	}
+	if got.Packages["pkg-a"] != "deadbeef" {
+		t.Errorf("got packages %v, want pkg-a = deadbeef", got.Packages)
+	}
+}
@@ -0,0 +1,202 @@
+package scope
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/pkg/errors"
+	"github.com/vercel/turborepo/cli/internal/scm"
+)
+
+// ChangeSource abstracts where turbo gets its "what changed" information
+// from. The git backend (the historical behavior, backed by scm.SCM) is one
+// implementation; mtime and workspace-dirty backends let turbo compute an
+// affected set without a reliable git history, e.g. in shallow checkouts or
+// release artifact pipelines.
+type ChangeSource interface {
+	// ChangedFiles returns the repo-relative paths that differ between from
+	// and to. Backends that don't use refs (mtime, workspace) ignore the
+	// arguments and compare against their own notion of a baseline.
+	ChangedFiles(from string, to string) ([]string, error)
+}
+
+// DefaultFingerprintManifestPath is where the mtime change source persists
+// its recorded fingerprints.
+const DefaultFingerprintManifestPath = ".turbo/fingerprints.json"
+
+// newChangeSource builds the ChangeSource named by kind ("git", "mtime", or
+// "workspace"). gitSCM is the existing scm.SCM implementation, used only by
+// the git backend; the workspace backend runs `git diff` itself.
+func newChangeSource(kind string, gitSCM scm.SCM, repoRoot string, cwd string) (ChangeSource, error) {
+	switch kind {
+	case "", "git":
+		return &gitChangeSource{scm: gitSCM, cwd: cwd}, nil
+	case "mtime":
+		return &mtimeChangeSource{repoRoot: repoRoot, manifestPath: filepath.Join(repoRoot, DefaultFingerprintManifestPath)}, nil
+	case "workspace":
+		return &workspaceChangeSource{repoRoot: repoRoot}, nil
+	default:
+		return nil, errors.Errorf("unknown change source %q, expected one of \"git\", \"mtime\", \"workspace\"", kind)
+	}
+}
+
+// gitChangeSource adapts the existing scm.SCM to the ChangeSource interface.
+type gitChangeSource struct {
+	scm scm.SCM
+	cwd string
+}
+
+func (g *gitChangeSource) ChangedFiles(from string, to string) ([]string, error) {
+	if from == "" {
+		return nil, nil
+	}
+	return g.scm.ChangedFiles(from, to, true, g.cwd)
+}
+
+// mtimeChangeSource fingerprints files by modification time and size, in the
+// spirit of Cargo's PathSource, and persists the fingerprints it has seen to
+// a manifest on disk so later invocations can tell what changed.
+type mtimeChangeSource struct {
+	repoRoot     string
+	manifestPath string
+}
+
+type fileFingerprint struct {
+	ModTime int64 `json:"modTime"`
+	Size    int64 `json:"size"`
+}
+
+type fingerprintManifest struct {
+	Files map[string]fileFingerprint `json:"files"`
+}
+
+func readFingerprintManifest(path string) (*fingerprintManifest, error) {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &fingerprintManifest{Files: map[string]fileFingerprint{}}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read fingerprint manifest %v", path)
+	}
+	manifest := &fingerprintManifest{}
+	if err := json.Unmarshal(contents, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse fingerprint manifest %v", path)
+	}
+	return manifest, nil
+}
+
+func writeFingerprintManifest(path string, manifest *fingerprintManifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create fingerprint manifest directory for %v", path)
+	}
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize fingerprint manifest")
+	}
+	return errors.Wrapf(os.WriteFile(path, contents, 0644), "failed to write fingerprint manifest %v", path)
+}
+
+// ChangedFiles walks the repo, honoring .gitignore-style patterns, and
+// compares each non-ignored file's current fingerprint to what's recorded in the
+// manifest, then rewrites the manifest with the current state. from/to are
+// ignored since this backend has no concept of refs.
+func (m *mtimeChangeSource) ChangedFiles(_ string, _ string) ([]string, error) {
+	manifest, err := readFingerprintManifest(m.manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := loadIgnoreMatcher(m.repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	current := map[string]fileFingerprint{}
+	walkErr := filepath.Walk(m.repoRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, relErr := filepath.Rel(m.repoRoot, path)
+		if relErr != nil {
+			return relErr
+		}
+		if info.IsDir() {
+			// .turbo holds this backend's own fingerprint manifest (and other
+			// turbo-owned cache state); walking into it would make the manifest
+			// write on each run perpetually show up as a change on the next one.
+			// Skip it unconditionally rather than relying on an optional
+			// .gitignore entry, since this backend is aimed at shallow
+			// checkouts / release artifacts that may not even have one.
+			if info.Name() == ".git" || info.Name() == ".turbo" || ignore.matches(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.matches(rel, false) {
+			return nil
+		}
+		fp := fileFingerprint{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+		current[rel] = fp
+		if prev, ok := manifest.Files[rel]; !ok || prev != fp {
+			changed = append(changed, rel)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, errors.Wrapf(walkErr, "failed to walk %v", m.repoRoot)
+	}
+	for rel := range manifest.Files {
+		if _, ok := current[rel]; !ok {
+			// File was removed since the last recorded fingerprint.
+			changed = append(changed, rel)
+		}
+	}
+	if err := writeFingerprintManifest(m.manifestPath, &fingerprintManifest{Files: current}); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// workspaceChangeSource treats every uncommitted-but-tracked file as changed,
+// regardless of which ref is asked about, for --change-source=workspace.
+type workspaceChangeSource struct {
+	repoRoot string
+}
+
+func (w *workspaceChangeSource) ChangedFiles(_ string, _ string) ([]string, error) {
+	out, err := exec.Command("git", "-C", w.repoRoot, "diff", "--name-only", "HEAD").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list uncommitted tracked files")
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			files = append(files, trimmed)
+		}
+	}
+	return files, nil
+}
+
+// ignoreMatcher wraps go-git's gitignore matcher so the mtime backend honors
+// .gitignore the same way git itself would: nested .gitignore files,
+// "!"-negation, and "**"/directory-only ("/"-suffixed) semantics all come
+// from go-git rather than a hand-rolled subset of them.
+type ignoreMatcher struct {
+	matcher gitignore.Matcher
+}
+
+func loadIgnoreMatcher(repoRoot string) (*ignoreMatcher, error) {
+	patterns, err := gitignore.ReadPatterns(osfs.New(repoRoot), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read .gitignore patterns")
+	}
+	return &ignoreMatcher{matcher: gitignore.NewMatcher(patterns)}, nil
+}
+
+func (m *ignoreMatcher) matches(relPath string, isDir bool) bool {
+	return m.matcher.Match(strings.Split(filepath.ToSlash(relPath), "/"), isDir)
+}
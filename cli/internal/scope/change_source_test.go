@@ -0,0 +1,169 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadIgnoreMatcher_MissingGitignoreIgnoresNothing(t *testing.T) {
+	m, err := loadIgnoreMatcher(t.TempDir())
+	if err != nil {
+		t.Fatalf("loadIgnoreMatcher() error: %v", err)
+	}
+	if m.matches("index.js", false) {
+		t.Errorf("expected no patterns for a repo with no .gitignore")
+	}
+}
+
+func TestLoadIgnoreMatcher_ParsesGitignore(t *testing.T) {
+	root := t.TempDir()
+	contents := "# comment\n\n/node_modules/\ndist\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write fixture .gitignore: %v", err)
+	}
+	m, err := loadIgnoreMatcher(root)
+	if err != nil {
+		t.Fatalf("loadIgnoreMatcher() error: %v", err)
+	}
+	cases := []struct {
+		name    string
+		relPath string
+		isDir   bool
+		want    bool
+	}{
+		{"tracked source file", "packages/a/index.js", false, false},
+		{"root-anchored dir match", "node_modules", true, true},
+		{"nested dir does not match root-anchored pattern", "packages/a/node_modules", true, false},
+		{"unanchored pattern matches anywhere", "packages/a/dist", true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := m.matches(tc.relPath, tc.isDir); got != tc.want {
+				t.Errorf("matches(%q) = %v, want %v", tc.relPath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadIgnoreMatcher_HonorsNegation(t *testing.T) {
+	root := t.TempDir()
+	contents := "*.log\n!keep.log\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write fixture .gitignore: %v", err)
+	}
+	m, err := loadIgnoreMatcher(root)
+	if err != nil {
+		t.Fatalf("loadIgnoreMatcher() error: %v", err)
+	}
+	if !m.matches("debug.log", false) {
+		t.Errorf("expected debug.log to be ignored")
+	}
+	if m.matches("keep.log", false) {
+		t.Errorf("expected keep.log to be un-ignored by the negated pattern")
+	}
+}
+
+func TestLoadIgnoreMatcher_ReadsNestedGitignore(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "packages", "a"), 0755); err != nil {
+		t.Fatalf("failed to create fixture dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "packages", "a", ".gitignore"), []byte("build\n"), 0644); err != nil {
+		t.Fatalf("failed to write fixture .gitignore: %v", err)
+	}
+	m, err := loadIgnoreMatcher(root)
+	if err != nil {
+		t.Fatalf("loadIgnoreMatcher() error: %v", err)
+	}
+	if !m.matches("packages/a/build", true) {
+		t.Errorf("expected packages/a/build to be ignored by the nested .gitignore")
+	}
+	if m.matches("packages/b/build", true) {
+		t.Errorf("expected packages/b/build to be unaffected by a .gitignore scoped to packages/a")
+	}
+}
+
+func TestFingerprintManifest_WriteReadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	manifest := &fingerprintManifest{Files: map[string]fileFingerprint{
+		"packages/a/index.js": {ModTime: 123, Size: 456},
+	}}
+	if err := writeFingerprintManifest(path, manifest); err != nil {
+		t.Fatalf("writeFingerprintManifest() error: %v", err)
+	}
+	got, err := readFingerprintManifest(path)
+	if err != nil {
+		t.Fatalf("readFingerprintManifest() error: %v", err)
+	}
+	if fp := got.Files["packages/a/index.js"]; fp.ModTime != 123 || fp.Size != 456 {
+		t.Errorf("got fingerprint %+v, want {123 456}", fp)
+	}
+}
+
+func TestReadFingerprintManifest_MissingFileIsEmpty(t *testing.T) {
+	manifest, err := readFingerprintManifest(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("readFingerprintManifest() error: %v", err)
+	}
+	if len(manifest.Files) != 0 {
+		t.Errorf("expected no files for a manifest that was never written, got %v", manifest.Files)
+	}
+}
+
+func TestMtimeChangeSource_DetectsNewAndModifiedFiles(t *testing.T) {
+	root := t.TempDir()
+	manifestPath := filepath.Join(root, DefaultFingerprintManifestPath)
+	filePath := filepath.Join(root, "index.js")
+	if err := os.WriteFile(filePath, []byte("v1"), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	source := &mtimeChangeSource{repoRoot: root, manifestPath: manifestPath}
+
+	changed, err := source.ChangedFiles("", "")
+	if err != nil {
+		t.Fatalf("ChangedFiles() error: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != "index.js" {
+		t.Fatalf("expected [index.js] on first run, got %v", changed)
+	}
+
+	changed, err = source.ChangedFiles("", "")
+	if err != nil {
+		t.Fatalf("ChangedFiles() error: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("expected no changes on second run with no edits, got %v", changed)
+	}
+}
+
+// TestMtimeChangeSource_IgnoresOwnManifestDirectory is the faithful
+// reproduction from the review: with the manifest under a real .turbo/
+// subdirectory of repoRoot (as DefaultFingerprintManifestPath puts it), the
+// walk must not fingerprint the manifest file it's about to (re)write, or
+// the manifest would always be "one write behind" its own on-disk mtime/size
+// and get reported as changed on every run forever.
+func TestMtimeChangeSource_IgnoresOwnManifestDirectory(t *testing.T) {
+	root := t.TempDir()
+	manifestPath := filepath.Join(root, DefaultFingerprintManifestPath)
+	if err := os.WriteFile(filepath.Join(root, "index.js"), []byte("v1"), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	source := &mtimeChangeSource{repoRoot: root, manifestPath: manifestPath}
+
+	for i := 0; i < 3; i++ {
+		changed, err := source.ChangedFiles("", "")
+		if err != nil {
+			t.Fatalf("ChangedFiles() error on run %d: %v", i, err)
+		}
+		for _, file := range changed {
+			if strings.HasPrefix(filepath.ToSlash(file), ".turbo/") {
+				t.Fatalf("run %d reported %v as changed; .turbo/ should never be walked", i, file)
+			}
+		}
+		if i > 0 && len(changed) != 0 {
+			t.Errorf("run %d: expected no changes once the manifest has stabilized, got %v", i, changed)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package scope
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"github.com/vercel/turborepo/cli/internal/util/filter"
+)
+
+// ExpansionPolicy controls how far a filter pattern's "..." dependent
+// expansion walks the dependency graph. The zero value expands dependents
+// with no limit, matching the pre-existing "..." behavior.
+type ExpansionPolicy struct {
+	// MaxDepth bounds the BFS to N graph hops from the matched packages. 0
+	// means unlimited.
+	MaxDepth int
+	// TestsOnly restricts expansion to packages that declare themselves as
+	// test consumers.
+	TestsOnly bool
+	// StopAtGlobs halts the dependent walk along any branch once it reaches
+	// a package matching one of these globs; the matching package itself is
+	// still included.
+	StopAtGlobs []string
+}
+
+var policyTokenRe = regexp.MustCompile(`\{([^}]*)\}`)
+
+// ParseExpansionPolicy extracts any trailing "{depth:N}", "{tests-only}", or
+// "{stop-at:<pkg-glob>}" tokens from a filter pattern and returns the pattern
+// with those tokens stripped, plus the policy they describe. A pattern with
+// no tokens returns the pattern unchanged and the zero-value (unlimited)
+// policy, so this mirrors the existing "...pkg" expansion when unused.
+func ParseExpansionPolicy(pattern string) (string, ExpansionPolicy, error) {
+	policy := ExpansionPolicy{}
+	clean := policyTokenRe.ReplaceAllStringFunc(pattern, func(token string) string {
+		body := strings.TrimSuffix(strings.TrimPrefix(token, "{"), "}")
+		switch {
+		case strings.HasPrefix(body, "depth:"):
+			policy.MaxDepth, _ = strconv.Atoi(strings.TrimPrefix(body, "depth:"))
+		case body == "tests-only":
+			policy.TestsOnly = true
+		case strings.HasPrefix(body, "stop-at:"):
+			policy.StopAtGlobs = append(policy.StopAtGlobs, strings.TrimPrefix(body, "stop-at:"))
+		default:
+			// Not one of our tokens (e.g. a literal brace in a glob); leave it alone.
+			return token
+		}
+		return ""
+	})
+	return clean, policy, nil
+}
+
+// dependentsLookup returns the packages that directly depend on pkg.
+type dependentsLookup func(pkg interface{}) []interface{}
+
+// testConsumerCheck reports whether pkg declares itself as a test consumer
+// (see isTestConsumer in scope.go). Only consulted when policy.TestsOnly is
+// set, so callers may pass nil for policies that don't use it.
+type testConsumerCheck func(pkg interface{}) bool
+
+// ExpandDependents performs a depth-limited BFS over dependents of start,
+// applying policy's constraints. It's called from ResolvePackages
+// (expandPolicyDependents in scope.go) for every --scope pattern that
+// carries a "{depth:N}"/"{tests-only}"/"{stop-at:...}" token, using
+// reverseDependencyIndex as the dependentsOf lookup. The walk itself always
+// traverses every dependent edge (so a test consumer reachable only through
+// a non-test intermediate package is still found); policy.TestsOnly instead
+// restricts which dependents are added to the result.
+func ExpandDependents(start util.Set, policy ExpansionPolicy, dependentsOf dependentsLookup, isTestConsumer testConsumerCheck) (util.Set, error) {
+	stopAtGlob, err := filter.Compile(policy.StopAtGlobs)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid stop-at glob")
+	}
+
+	result := make(util.Set)
+	visited := make(map[interface{}]bool)
+	type queued struct {
+		pkg   interface{}
+		depth int
+	}
+	var queue []queued
+	for pkg := range start {
+		result.Add(pkg)
+		visited[pkg] = true
+		queue = append(queue, queued{pkg: pkg, depth: 0})
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		if policy.MaxDepth > 0 && item.depth >= policy.MaxDepth {
+			continue
+		}
+		if name, ok := item.pkg.(string); ok && stopAtGlob != nil && stopAtGlob.Match(name) {
+			// This package itself is included (already added above), but we
+			// don't walk past it.
+			continue
+		}
+
+		for _, dependent := range dependentsOf(item.pkg) {
+			if visited[dependent] {
+				continue
+			}
+			visited[dependent] = true
+			if !policy.TestsOnly || (isTestConsumer != nil && isTestConsumer(dependent)) {
+				result.Add(dependent)
+			}
+			queue = append(queue, queued{pkg: dependent, depth: item.depth + 1})
+		}
+	}
+	return result, nil
+}
+
+// reverseDependencyIndex builds the dependentsOf lookup ExpandDependents
+// needs, from each package's declared internal dependencies (the same data
+// hash.go's packageContentHash walks). packageInfos is already fully loaded
+// in memory by the caller, so this is a single pass over data we already
+// have — cheaper than any disk-backed cache could be (an earlier version of
+// this function persisted the result to a .turbo/graph-cache/*.gob keyed by
+// a content hash of every manifest in the repo; computing that key cost more
+// I/O than just rebuilding the index, so it was removed rather than fixed).
+// cwd is unused; it's kept so call sites don't need to change if this does
+// grow a real need for repo-relative context later.
+func reverseDependencyIndex(cwd string, packageInfos map[interface{}]*fs.PackageJSON) (map[interface{}][]interface{}, error) {
+	return buildReverseDependencyIndex(packageInfos), nil
+}
+
+// buildReverseDependencyIndex inverts each package's internal dependencies
+// into a pkg -> dependents map.
+func buildReverseDependencyIndex(packageInfos map[interface{}]*fs.PackageJSON) map[interface{}][]interface{} {
+	index := make(map[interface{}][]interface{})
+	for pkgName, pkgInfo := range packageInfos {
+		for dep := range pkgInfo.InternalDeps {
+			index[dep] = append(index[dep], pkgName)
+		}
+	}
+	return index
+}
@@ -0,0 +1,108 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/util"
+)
+
+func TestParseExpansionPolicy(t *testing.T) {
+	cases := []struct {
+		name       string
+		pattern    string
+		wantClean  string
+		wantPolicy ExpansionPolicy
+	}{
+		{"no tokens", "my-pkg", "my-pkg", ExpansionPolicy{}},
+		{"depth", "my-pkg{depth:2}", "my-pkg", ExpansionPolicy{MaxDepth: 2}},
+		{"tests-only", "my-pkg{tests-only}", "my-pkg", ExpansionPolicy{TestsOnly: true}},
+		{"stop-at", "my-pkg{stop-at:apps/*}", "my-pkg", ExpansionPolicy{StopAtGlobs: []string{"apps/*"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clean, policy, err := ParseExpansionPolicy(tc.pattern)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if clean != tc.wantClean {
+				t.Errorf("clean pattern = %q, want %q", clean, tc.wantClean)
+			}
+			if policy.MaxDepth != tc.wantPolicy.MaxDepth || policy.TestsOnly != tc.wantPolicy.TestsOnly || len(policy.StopAtGlobs) != len(tc.wantPolicy.StopAtGlobs) {
+				t.Errorf("policy = %+v, want %+v", policy, tc.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestExpandDependents_RespectsMaxDepth(t *testing.T) {
+	// a <- b <- c <- d (b, c, d each depend on the previous package)
+	graph := map[interface{}][]interface{}{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"d"},
+	}
+	dependentsOf := func(pkg interface{}) []interface{} { return graph[pkg] }
+
+	start := make(util.Set)
+	start.Add("a")
+
+	expanded, err := ExpandDependents(start, ExpansionPolicy{MaxDepth: 1}, dependentsOf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"a", "b"} {
+		if _, ok := expanded[want]; !ok {
+			t.Errorf("expected %v in expanded set %v", want, expanded)
+		}
+	}
+	if _, ok := expanded["c"]; ok {
+		t.Errorf("expected c to be excluded at depth 1, got %v", expanded)
+	}
+}
+
+func TestExpandDependents_StopAt(t *testing.T) {
+	graph := map[interface{}][]interface{}{
+		"a": {"b"},
+		"b": {"c"},
+	}
+	dependentsOf := func(pkg interface{}) []interface{} { return graph[pkg] }
+
+	start := make(util.Set)
+	start.Add("a")
+
+	expanded, err := ExpandDependents(start, ExpansionPolicy{StopAtGlobs: []string{"b"}}, dependentsOf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := expanded["b"]; !ok {
+		t.Errorf("expected stop-at package itself to be included, got %v", expanded)
+	}
+	if _, ok := expanded["c"]; ok {
+		t.Errorf("expected walk to stop at b, but c was included: %v", expanded)
+	}
+}
+
+func TestExpandDependents_TestsOnlyFiltersNonTestDependents(t *testing.T) {
+	// a <- b (not a test consumer) <- c (a test consumer)
+	graph := map[interface{}][]interface{}{
+		"a": {"b"},
+		"b": {"c"},
+	}
+	dependentsOf := func(pkg interface{}) []interface{} { return graph[pkg] }
+	testConsumers := map[interface{}]bool{"c": true}
+	isTestConsumer := func(pkg interface{}) bool { return testConsumers[pkg] }
+
+	start := make(util.Set)
+	start.Add("a")
+
+	expanded, err := ExpandDependents(start, ExpansionPolicy{TestsOnly: true}, dependentsOf, isTestConsumer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := expanded["b"]; ok {
+		t.Errorf("expected non-test dependent b to be excluded, got %v", expanded)
+	}
+	if _, ok := expanded["c"]; !ok {
+		t.Errorf("expected test consumer c (reachable through non-test b) to be included, got %v", expanded)
+	}
+}
@@ -0,0 +1,159 @@
+package scope
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"github.com/vercel/turborepo/cli/internal/util/filter"
+)
+
+// globalDepRule is a single parsed entry from Opts.GlobalDepPatterns. Plain
+// entries (no "=>") default to the "all" scope, preserving the pre-existing
+// "any match invalidates everything" behavior.
+type globalDepRule struct {
+	pattern string
+	scope   string // "all", "consumers", or "type"
+	arg     string // pkg-glob for "consumers", category key for "type"
+}
+
+// parseGlobalDepPatterns splits each raw --global-deps entry on "=>" to
+// recover its scope, e.g. "docker/**=>type:dockerfile".
+func parseGlobalDepPatterns(patterns []string) []globalDepRule {
+	rules := make([]globalDepRule, 0, len(patterns))
+	for _, raw := range patterns {
+		pattern := raw
+		scope := "all"
+		arg := ""
+		if idx := strings.Index(raw, "=>"); idx >= 0 {
+			pattern = strings.TrimSpace(raw[:idx])
+			rest := strings.TrimSpace(raw[idx+2:])
+			switch {
+			case rest == "all" || rest == "":
+				scope = "all"
+			case strings.HasPrefix(rest, "consumers:"):
+				scope = "consumers"
+				arg = strings.TrimPrefix(rest, "consumers:")
+			case strings.HasPrefix(rest, "type:"):
+				scope = "type"
+				arg = strings.TrimPrefix(rest, "type:")
+			default:
+				// Unrecognized scope; fall back to the safe "all" behavior
+				// rather than silently ignoring the file class.
+				scope = "all"
+			}
+		}
+		rules = append(rules, globalDepRule{pattern: pattern, scope: scope, arg: arg})
+	}
+	return rules
+}
+
+// globalDepFilePatterns returns just the file-matching glob from each
+// --global-deps entry, with any "=>scope" suffix stripped. Callers that only
+// care about "did a global dep file change" (e.g. content hashing) should use
+// this instead of compiling opts.GlobalDepPatterns directly, since compiling
+// the raw "pattern=>scope" string would never match a real file path.
+func globalDepFilePatterns(patterns []string) []string {
+	rules := parseGlobalDepPatterns(patterns)
+	filePatterns := make([]string, len(rules))
+	for i, rule := range rules {
+		filePatterns[i] = rule.pattern
+	}
+	return filePatterns
+}
+
+// turboJSONGlobalDepCategories is the subset of turbo.json this package
+// reads: a "globalDependencyCategories" map from category key (the part
+// after "type:" in a --global-deps rule) to the package names that should be
+// considered affected when that category changes.
+type turboJSONGlobalDepCategories struct {
+	GlobalDependencyCategories map[string][]string `json:"globalDependencyCategories"`
+}
+
+// loadGlobalDepCategories reads globalDependencyCategories out of
+// <repoRoot>/turbo.json. A missing file or missing field is not an error: it
+// just means no "type:<key>" rule will match anything, same as today.
+func loadGlobalDepCategories(repoRoot string) (map[string][]string, error) {
+	contents, err := os.ReadFile(filepath.Join(repoRoot, "turbo.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read turbo.json")
+	}
+	var parsed turboJSONGlobalDepCategories
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse turbo.json")
+	}
+	return parsed.GlobalDependencyCategories, nil
+}
+
+// affectedPackagesForGlobalDeps computes the union of packages invalidated by
+// changedFiles matching any global dep rule, classifying each match by its
+// rule's scope instead of unconditionally invalidating every package. An
+// "all"-scoped match still short-circuits to every package, since there's no
+// narrower set to compute. "type"-scoped rules are resolved against
+// opts.GlobalDepCategories, loading it from turbo.json on first use if the
+// caller hasn't already populated it.
+func affectedPackagesForGlobalDeps(opts *Opts, repoRoot string, changedFiles []string, packageInfos map[interface{}]*fs.PackageJSON) (util.Set, error) {
+	rules := parseGlobalDepPatterns(opts.GlobalDepPatterns)
+	affected := make(util.Set)
+	for _, rule := range rules {
+		ruleGlob, err := filter.Compile([]string{rule.pattern})
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid global deps glob %q", rule.pattern)
+		}
+		if ruleGlob == nil {
+			continue
+		}
+		matched := false
+		for _, file := range changedFiles {
+			if ruleGlob.Match(filepath.ToSlash(file)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		switch rule.scope {
+		case "consumers":
+			pkgGlob, err := filter.Compile([]string{rule.arg})
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid consumers glob %q", rule.arg)
+			}
+			for pkgName := range packageInfos {
+				if pkgName == util.RootPkgName {
+					continue
+				}
+				if pkgGlob == nil || pkgGlob.Match(pkgName.(string)) {
+					affected.Add(pkgName)
+				}
+			}
+		case "type":
+			if opts.GlobalDepCategories == nil {
+				categories, err := loadGlobalDepCategories(repoRoot)
+				if err != nil {
+					return nil, err
+				}
+				if categories == nil {
+					categories = map[string][]string{}
+				}
+				opts.GlobalDepCategories = categories
+			}
+			for _, pkgName := range opts.GlobalDepCategories[rule.arg] {
+				affected.Add(pkgName)
+			}
+		default: // "all"
+			for pkgName := range packageInfos {
+				affected.Add(pkgName)
+			}
+			return affected, nil
+		}
+	}
+	return affected, nil
+}
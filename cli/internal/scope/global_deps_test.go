@@ -0,0 +1,149 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+func TestParseGlobalDepPatterns(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want globalDepRule
+	}{
+		{
+			name: "plain pattern defaults to all",
+			raw:  ".env",
+			want: globalDepRule{pattern: ".env", scope: "all"},
+		},
+		{
+			name: "explicit all",
+			raw:  ".env=>all",
+			want: globalDepRule{pattern: ".env", scope: "all"},
+		},
+		{
+			name: "consumers scope",
+			raw:  "docker/**=>consumers:api-*",
+			want: globalDepRule{pattern: "docker/**", scope: "consumers", arg: "api-*"},
+		},
+		{
+			name: "type scope",
+			raw:  "Dockerfile=>type:dockerfile",
+			want: globalDepRule{pattern: "Dockerfile", scope: "type", arg: "dockerfile"},
+		},
+		{
+			name: "unrecognized scope falls back to all",
+			raw:  ".env=>bogus",
+			want: globalDepRule{pattern: ".env", scope: "all"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseGlobalDepPatterns([]string{tc.raw})
+			if len(got) != 1 || got[0] != tc.want {
+				t.Errorf("parseGlobalDepPatterns(%q) = %+v, want %+v", tc.raw, got, []globalDepRule{tc.want})
+			}
+		})
+	}
+}
+
+func TestGlobalDepFilePatternsStripsScopeSuffix(t *testing.T) {
+	got := globalDepFilePatterns([]string{"docker/**=>type:dockerfile", ".env=>consumers:api-*", "turbo.json"})
+	want := []string{"docker/**", ".env", "turbo.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("globalDepFilePatterns() = %v, want %v", got, want)
+	}
+}
+
+func testPackageInfos(names ...string) map[interface{}]*fs.PackageJSON {
+	infos := make(map[interface{}]*fs.PackageJSON, len(names))
+	for _, name := range names {
+		infos[name] = &fs.PackageJSON{}
+	}
+	return infos
+}
+
+func TestAffectedPackagesForGlobalDeps_AllScope(t *testing.T) {
+	opts := &Opts{GlobalDepPatterns: []string{".env"}}
+	infos := testPackageInfos("api", "web")
+	affected, err := affectedPackagesForGlobalDeps(opts, t.TempDir(), []string{".env"}, infos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"api", "web"} {
+		if _, ok := affected[want]; !ok {
+			t.Errorf("expected %v in affected set %v", want, affected)
+		}
+	}
+}
+
+func TestAffectedPackagesForGlobalDeps_ConsumersScope(t *testing.T) {
+	opts := &Opts{GlobalDepPatterns: []string{"docker/**=>consumers:api-*"}}
+	infos := testPackageInfos("api-a", "api-b", "web")
+	affected, err := affectedPackagesForGlobalDeps(opts, t.TempDir(), []string{"docker/Dockerfile"}, infos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"api-a", "api-b"} {
+		if _, ok := affected[want]; !ok {
+			t.Errorf("expected %v in affected set %v", want, affected)
+		}
+	}
+	if _, ok := affected["web"]; ok {
+		t.Errorf("expected web to be excluded, got %v", affected)
+	}
+}
+
+func TestAffectedPackagesForGlobalDeps_TypeScope_UsesOptsCategories(t *testing.T) {
+	opts := &Opts{
+		GlobalDepPatterns:   []string{"Dockerfile=>type:dockerfile"},
+		GlobalDepCategories: map[string][]string{"dockerfile": {"api"}},
+	}
+	infos := testPackageInfos("api", "web")
+	affected, err := affectedPackagesForGlobalDeps(opts, t.TempDir(), []string{"Dockerfile"}, infos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := affected["api"]; !ok {
+		t.Errorf("expected api in affected set %v", affected)
+	}
+	if _, ok := affected["web"]; ok {
+		t.Errorf("expected web to be excluded, got %v", affected)
+	}
+}
+
+func TestAffectedPackagesForGlobalDeps_TypeScope_LoadsCategoriesFromTurboJSON(t *testing.T) {
+	repoRoot := t.TempDir()
+	turboJSON := `{"globalDependencyCategories": {"dockerfile": ["api"]}}`
+	if err := os.WriteFile(filepath.Join(repoRoot, "turbo.json"), []byte(turboJSON), 0644); err != nil {
+		t.Fatalf("failed to write turbo.json: %v", err)
+	}
+	opts := &Opts{GlobalDepPatterns: []string{"Dockerfile=>type:dockerfile"}}
+	infos := testPackageInfos("api", "web")
+	affected, err := affectedPackagesForGlobalDeps(opts, repoRoot, []string{"Dockerfile"}, infos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := affected["api"]; !ok {
+		t.Errorf("expected api in affected set %v", affected)
+	}
+	if _, ok := affected["web"]; ok {
+		t.Errorf("expected web to be excluded, got %v", affected)
+	}
+}
+
+func TestAffectedPackagesForGlobalDeps_TypeScope_NoCategoriesMatchesNothing(t *testing.T) {
+	opts := &Opts{GlobalDepPatterns: []string{"Dockerfile=>type:dockerfile"}}
+	infos := testPackageInfos("api", "web")
+	affected, err := affectedPackagesForGlobalDeps(opts, t.TempDir(), []string{"Dockerfile"}, infos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(affected) != 0 {
+		t.Errorf("expected no affected packages, got %v", affected)
+	}
+}
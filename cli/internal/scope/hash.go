@@ -0,0 +1,220 @@
+package scope
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"github.com/vercel/turborepo/cli/internal/util/filter"
+)
+
+// DefaultBaselinePath is where the content-hash baseline manifest is stored
+// when the user doesn't supply their own path via --since-hash.
+const DefaultBaselinePath = ".turbo/baseline.json"
+
+// BaselineManifest is the serialized form of a content-hash baseline: a
+// package name to content hash mapping, captured at some point in time (e.g.
+// a release) so later invocations can ask "what changed since then" without
+// relying on git history being available.
+type BaselineManifest struct {
+	Packages map[string]string `json:"packages"`
+}
+
+// ReadBaselineManifest loads a previously-written baseline manifest from path.
+func ReadBaselineManifest(path string) (*BaselineManifest, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read baseline manifest %v", path)
+	}
+	manifest := &BaselineManifest{}
+	if err := json.Unmarshal(contents, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse baseline manifest %v", path)
+	}
+	return manifest, nil
+}
+
+// WriteBaselineManifest serializes manifest to path, creating its parent
+// directory if necessary.
+func WriteBaselineManifest(path string, manifest *BaselineManifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create baseline manifest directory for %v", path)
+	}
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize baseline manifest")
+	}
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write baseline manifest %v", path)
+	}
+	return nil
+}
+
+// ComputeBaselineManifest computes a content hash for every workspace package,
+// suitable for writing out with WriteBaselineManifest.
+func ComputeBaselineManifest(opts *Opts, repoRoot string, packageInfos map[interface{}]*fs.PackageJSON) (*BaselineManifest, error) {
+	manifest := &BaselineManifest{Packages: map[string]string{}}
+	for pkgName, pkgInfo := range packageInfos {
+		if pkgName == util.RootPkgName {
+			continue
+		}
+		hash, err := packageContentHash(opts, repoRoot, pkgInfo, packageInfos)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to hash package %v", pkgName)
+		}
+		manifest.Packages[pkgName.(string)] = hash
+	}
+	return manifest, nil
+}
+
+// packageContentHash computes a stable hash over a package's own files, the
+// content hashes of its resolved internal dependencies, and any root global
+// files matched by opts.GlobalDepPatterns. Internal dependencies are hashed
+// in before recursing so a change in a transitive dependency also changes the
+// hash of everything that depends on it.
+func packageContentHash(opts *Opts, repoRoot string, pkgInfo *fs.PackageJSON, packageInfos map[interface{}]*fs.PackageJSON) (string, error) {
+	h := sha256.New()
+
+	files, err := sortedPackageFiles(filepath.Join(repoRoot, pkgInfo.Dir.ToStringDuringMigration()))
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(repoRoot, file)
+		if err != nil {
+			return "", err
+		}
+		if err := hashFileInto(h, file, rel); err != nil {
+			return "", err
+		}
+	}
+
+	for _, dep := range sortedInternalDeps(pkgInfo) {
+		depInfo, ok := packageInfos[dep]
+		if !ok {
+			continue
+		}
+		depHash, err := packageContentHash(opts, repoRoot, depInfo, packageInfos)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(h, depHash)
+	}
+
+	globalGlob, err := filter.Compile(globalDepFilePatterns(opts.GlobalDepPatterns))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid global deps glob")
+	}
+	if globalGlob != nil {
+		rootFiles, err := sortedPackageFiles(repoRoot)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range rootFiles {
+			rel, err := filepath.Rel(repoRoot, file)
+			if err != nil {
+				return "", err
+			}
+			if globalGlob.Match(filepath.ToSlash(rel)) {
+				if err := hashFileInto(h, file, rel); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedPackageFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk %v", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// hashFileInto writes path's contents into h, identified by repoRelPath (its
+// path relative to repoRoot) rather than its absolute path, so the resulting
+// hash is stable across checkouts at different absolute locations (shallow
+// clones, CI workspaces, release-artifact unpacks) — exactly the scenarios
+// --since-hash targets.
+func hashFileInto(h io.Writer, path string, repoRelPath string) error {
+	io.WriteString(h, filepath.ToSlash(repoRelPath))
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %v", path)
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+func sortedInternalDeps(pkgInfo *fs.PackageJSON) []interface{} {
+	deps := make([]string, 0, len(pkgInfo.InternalDeps))
+	for dep := range pkgInfo.InternalDeps {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	result := make([]interface{}, len(deps))
+	for i, dep := range deps {
+		result[i] = dep
+	}
+	return result
+}
+
+// getPackageChangeFuncFromHash returns a PackagesChangedInRange-shaped
+// function (see scope_filter.PackagesChangedInRange) that ignores its ref
+// arguments entirely: instead of diffing git refs, it recomputes each
+// package's content hash and compares against the baseline manifest at
+// opts.SinceHashBaseline, reporting a package as changed whenever its hash
+// differs (including packages missing from the baseline entirely). The
+// directly-changed set is then expanded to its transitively-affected
+// dependents via the same reverse-dependency index ExpandDependents uses
+// (see reverseDependencyIndex in expansion_policy.go), with no depth limit,
+// so a changed leaf dependency also marks its unhashed-but-dependent
+// packages as changed.
+func (o *Opts) getPackageChangeFuncFromHash(repoRoot string, packageInfos map[interface{}]*fs.PackageJSON) func(fromRef string, toRef string) (util.Set, error) {
+	return func(_ string, _ string) (util.Set, error) {
+		baseline, err := ReadBaselineManifest(o.SinceHashBaseline)
+		if err != nil {
+			return nil, err
+		}
+		current, err := ComputeBaselineManifest(o, repoRoot, packageInfos)
+		if err != nil {
+			return nil, err
+		}
+		changed := make(util.Set)
+		for pkgName, hash := range current.Packages {
+			if baseline.Packages[pkgName] != hash {
+				changed.Add(pkgName)
+			}
+		}
+		index, err := reverseDependencyIndex(repoRoot, packageInfos)
+		if err != nil {
+			return nil, err
+		}
+		dependentsOf := func(pkg interface{}) []interface{} { return index[pkg] }
+		return ExpandDependents(changed, ExpansionPolicy{}, dependentsOf, nil)
+	}
+}
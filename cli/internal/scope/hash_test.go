@@ -0,0 +1,116 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/util"
+)
+
+// writeRepoFixture creates relFiles (all with distinct contents derived from
+// their own path) inside a fresh repoRoot, returning repoRoot.
+func writeRepoFixture(t *testing.T, relFiles ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, rel := range relFiles {
+		full := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create fixture dir for %v: %v", rel, err)
+		}
+		if err := os.WriteFile(full, []byte("contents of "+rel), 0644); err != nil {
+			t.Fatalf("failed to write fixture file %v: %v", rel, err)
+		}
+	}
+	return root
+}
+
+func TestPackageContentHash_StableAcrossCheckoutLocations(t *testing.T) {
+	rootA := writeRepoFixture(t, "index.js")
+	rootB := writeRepoFixture(t, "index.js")
+
+	opts := &Opts{}
+	infos := testPackageInfos("pkg-a")
+
+	hashA, err := packageContentHash(opts, rootA, infos["pkg-a"], infos)
+	if err != nil {
+		t.Fatalf("packageContentHash(rootA) error: %v", err)
+	}
+	hashB, err := packageContentHash(opts, rootB, infos["pkg-a"], infos)
+	if err != nil {
+		t.Fatalf("packageContentHash(rootB) error: %v", err)
+	}
+	if hashA != hashB {
+		t.Errorf("expected identical hashes for identical relative content at different checkout paths, got %v != %v", hashA, hashB)
+	}
+}
+
+func TestPackageContentHash_ChangesWithContent(t *testing.T) {
+	root := writeRepoFixture(t, "index.js")
+	opts := &Opts{}
+	infos := testPackageInfos("pkg-a")
+
+	before, err := packageContentHash(opts, root, infos["pkg-a"], infos)
+	if err != nil {
+		t.Fatalf("packageContentHash() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "index.js"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to rewrite fixture file: %v", err)
+	}
+	after, err := packageContentHash(opts, root, infos["pkg-a"], infos)
+	if err != nil {
+		t.Fatalf("packageContentHash() error: %v", err)
+	}
+	if before == after {
+		t.Errorf("expected hash to change with file contents, got the same value %v both times", before)
+	}
+}
+
+func TestComputeBaselineManifest_SkipsRootPackage(t *testing.T) {
+	root := writeRepoFixture(t, "index.js")
+	opts := &Opts{}
+	infos := testPackageInfos("pkg-a", "pkg-b")
+	infos[util.RootPkgName] = infos["pkg-a"]
+
+	manifest, err := ComputeBaselineManifest(opts, root, infos)
+	if err != nil {
+		t.Fatalf("ComputeBaselineManifest() error: %v", err)
+	}
+	if _, ok := manifest.Packages[util.RootPkgName.(string)]; ok {
+		t.Errorf("expected root package to be skipped, got %v", manifest.Packages)
+	}
+	for _, want := range []string{"pkg-a", "pkg-b"} {
+		if _, ok := manifest.Packages[want]; !ok {
+			t.Errorf("expected %v in baseline manifest, got %v", want, manifest.Packages)
+		}
+	}
+}
+
+func TestGetPackageChangeFuncFromHash_ReportsChangedPackages(t *testing.T) {
+	root := writeRepoFixture(t, "index.js")
+	opts := &Opts{SinceHashBaseline: filepath.Join(t.TempDir(), "baseline.json")}
+	infos := testPackageInfos("pkg-a", "pkg-b")
+
+	baseline, err := ComputeBaselineManifest(opts, root, infos)
+	if err != nil {
+		t.Fatalf("ComputeBaselineManifest() error: %v", err)
+	}
+	if err := WriteBaselineManifest(opts.SinceHashBaseline, baseline); err != nil {
+		t.Fatalf("WriteBaselineManifest() error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "index.js"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to rewrite fixture file: %v", err)
+	}
+
+	changeFunc := opts.getPackageChangeFuncFromHash(root, infos)
+	changed, err := changeFunc("", "")
+	if err != nil {
+		t.Fatalf("getPackageChangeFuncFromHash() error: %v", err)
+	}
+	for _, want := range []string{"pkg-a", "pkg-b"} {
+		if _, ok := changed[want]; !ok {
+			t.Errorf("expected %v in changed set, got %v", want, changed)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package scope
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+)
+
+// sourceParser extracts the files that a single source file imports, resolved
+// to repo-relative paths matching the format SCM reports changed files in.
+// Implementations that can't confidently resolve every import for a file
+// should return ok=false so callers fall back to directory-containment for
+// that package rather than risk a false negative.
+type sourceParser interface {
+	// canParse reports whether this parser understands the given file.
+	canParse(path string) bool
+	// imports returns the resolved paths path imports from, or ok=false if
+	// path couldn't be parsed or any of its imports couldn't be resolved.
+	imports(path string) (resolved []string, ok bool)
+}
+
+// defaultSourceParsers is the set of parsers consulted by source-aware change
+// detection, in order.
+func defaultSourceParsers(repoRoot string) []sourceParser {
+	return []sourceParser{
+		&goSourceParser{repoRoot: repoRoot},
+		&jsSourceParser{repoRoot: repoRoot},
+	}
+}
+
+// goSourceParser resolves imports for .go files using go/parser. It only
+// resolves imports that live inside the repo; std-lib and third-party
+// imports are dropped since they can't change as part of this repo's diff.
+type goSourceParser struct {
+	repoRoot string
+}
+
+func (p *goSourceParser) canParse(path string) bool {
+	return strings.HasSuffix(path, ".go")
+}
+
+func (p *goSourceParser) imports(path string) ([]string, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, false
+	}
+	dir := filepath.Dir(path)
+	var resolved []string
+	for _, imp := range f.Imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		if candidate := p.resolveLocal(dir, importPath); candidate != "" {
+			resolved = append(resolved, candidate)
+		}
+	}
+	return resolved, true
+}
+
+// resolveLocal maps an import path to a package directory inside the repo by
+// walking up from dir until it finds a go.mod, then joining the module's
+// import path prefix back to a filesystem path. Imports outside the repo
+// (anything that doesn't resolve to an existing directory under repoRoot)
+// are ignored.
+func (p *goSourceParser) resolveLocal(dir string, importPath string) string {
+	modRoot, modPath := p.findModule(dir)
+	if modRoot == "" || !strings.HasPrefix(importPath, modPath) {
+		return ""
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, modPath), "/")
+	candidate := filepath.Join(modRoot, filepath.FromSlash(rel))
+	if !strings.HasPrefix(candidate, p.repoRoot) {
+		return ""
+	}
+	if info, err := os.Stat(candidate); err != nil || !info.IsDir() {
+		return ""
+	}
+	return candidate
+}
+
+func (p *goSourceParser) findModule(dir string) (root string, modulePath string) {
+	for cur := dir; strings.HasPrefix(cur, p.repoRoot); cur = filepath.Dir(cur) {
+		modFile := filepath.Join(cur, "go.mod")
+		if contents, err := os.ReadFile(modFile); err == nil {
+			for _, line := range strings.Split(string(contents), "\n") {
+				if strings.HasPrefix(line, "module ") {
+					return cur, strings.TrimSpace(strings.TrimPrefix(line, "module "))
+				}
+			}
+		}
+		if cur == filepath.Dir(cur) {
+			break
+		}
+	}
+	return "", ""
+}
+
+// jsImportRe matches the specifier out of ES import/export-from statements
+// and CommonJS require() calls. It's intentionally permissive (no attempt to
+// parse a real JS/TS grammar, skip comments, or handle template literals) —
+// good enough to find the relative-import edges that matter for change
+// detection, not a full module resolver.
+var jsImportRe = regexp.MustCompile(`(?:from\s+|require\()\s*['"]([^'"]+)['"]`)
+
+// jsSourceParser resolves relative JS/TS imports (those starting with "."),
+// which is everything that can link two files within this repo. Bare
+// specifiers (package names, whether workspace packages or third-party) are
+// resolved at the package-dependency level already, not the file level, so
+// they're intentionally skipped here rather than treated as unresolvable.
+type jsSourceParser struct {
+	repoRoot string
+}
+
+func (p *jsSourceParser) canParse(path string) bool {
+	switch filepath.Ext(path) {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *jsSourceParser) imports(path string) ([]string, bool) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	dir := filepath.Dir(path)
+	var resolved []string
+	for _, match := range jsImportRe.FindAllStringSubmatch(string(contents), -1) {
+		specifier := match[1]
+		if !strings.HasPrefix(specifier, ".") {
+			// Not a relative import; either a bare package specifier (handled
+			// at the package-dependency level) or something we can't resolve
+			// to a specific file.
+			continue
+		}
+		candidate := p.resolveRelative(dir, specifier)
+		if candidate == "" {
+			// A relative import we can't find on disk means we can't prove
+			// reachability for this file; fall back to directory-containment
+			// for the whole package rather than risk under-reporting.
+			return nil, false
+		}
+		resolved = append(resolved, candidate)
+	}
+	return resolved, true
+}
+
+// jsResolveExtensions are tried, in order, against a bare relative specifier
+// and against specifier/index, mirroring Node's default resolution order
+// closely enough for the files this repo actually contains.
+var jsResolveExtensions = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+func (p *jsSourceParser) resolveRelative(dir string, specifier string) string {
+	base := filepath.Join(dir, filepath.FromSlash(specifier))
+	for _, ext := range jsResolveExtensions {
+		if candidate := base + ext; fileExists(candidate) {
+			return candidate
+		}
+	}
+	for _, ext := range jsResolveExtensions[1:] {
+		if candidate := filepath.Join(base, "index"+ext); fileExists(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// entrypointsFor returns the files that seed reachability analysis for a
+// workspace package: its package.json "main" target plus any additional
+// entrypoints the caller already knows about (e.g. turbo.json-configured
+// entrypoints, resolved relative to the package's directory).
+func entrypointsFor(pkgInfo *fs.PackageJSON, extra []string) []string {
+	pkgDir := pkgInfo.Dir.ToStringDuringMigration()
+	var entrypoints []string
+	if pkgInfo.Main != "" {
+		entrypoints = append(entrypoints, filepath.Join(pkgDir, pkgInfo.Main))
+	}
+	for _, e := range extra {
+		entrypoints = append(entrypoints, filepath.Join(pkgDir, e))
+	}
+	return entrypoints
+}
+
+// turboJSONPackageEntrypoints is the subset of turbo.json this package reads
+// to seed source-aware change detection for packages whose real entrypoints
+// aren't captured by package.json's "main" field (e.g. multi-entrypoint
+// JS/TS packages, or ones exposing subpaths via "exports" rather than
+// "main"). Each value is a list of paths relative to that package's directory.
+type turboJSONPackageEntrypoints struct {
+	PackageEntrypoints map[string][]string `json:"packageEntrypoints"`
+}
+
+// loadPackageEntrypoints reads packageEntrypoints out of <repoRoot>/turbo.json.
+// A missing file or missing field is not an error: it just means no package
+// gets additional entrypoints beyond its package.json "main".
+func loadPackageEntrypoints(repoRoot string) (map[string][]string, error) {
+	contents, err := os.ReadFile(filepath.Join(repoRoot, "turbo.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read turbo.json")
+	}
+	var parsed turboJSONPackageEntrypoints
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse turbo.json")
+	}
+	return parsed.PackageEntrypoints, nil
+}
+
+// buildImportMap walks the import graph starting from entrypoints and
+// returns every file transitively reachable from them, using the given
+// parsers. ok is false if any file along the walk couldn't be resolved,
+// which tells the caller to fall back to directory-containment for the
+// whole package rather than risk missing a changed file.
+func buildImportMap(entrypoints []string, parsers []sourceParser) (util.Set, bool) {
+	if len(entrypoints) == 0 {
+		// We have no idea what this package's entrypoints are (e.g. no
+		// "main" in package.json and no configured entrypoints), so we can't
+		// prove anything is or isn't reachable. Treat that the same as a
+		// parse failure rather than silently reporting "nothing reachable".
+		return nil, false
+	}
+	visited := make(map[string]bool)
+	reachable := make(util.Set)
+	queue := append([]string{}, entrypoints...)
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if visited[file] {
+			continue
+		}
+		visited[file] = true
+		reachable.Add(file)
+
+		var parser sourceParser
+		for _, candidate := range parsers {
+			if candidate.canParse(file) {
+				parser = candidate
+				break
+			}
+		}
+		if parser == nil {
+			// No parser understands this file; we can't prove what it does
+			// or doesn't import, so bail out to the safe fallback.
+			return nil, false
+		}
+		imports, ok := parser.imports(file)
+		if !ok {
+			return nil, false
+		}
+		queue = append(queue, imports...)
+	}
+	return reachable, true
+}
+
+// getChangedPackagesSourceAware is the source-aware counterpart to
+// getChangedPackages: a package is only marked changed if one of the changed
+// files is transitively imported from its entrypoints. Packages whose
+// entrypoints (or any file reachable from them) can't be parsed fall back to
+// directory-containment so we never under-report a changed package.
+func getChangedPackagesSourceAware(repoRoot string, changedFiles []string, packageInfos map[interface{}]*fs.PackageJSON) util.Set {
+	parsers := defaultSourceParsers(repoRoot)
+	changedSet := make(map[string]bool, len(changedFiles))
+	for _, file := range changedFiles {
+		changedSet[file] = true
+	}
+	// A missing or unparsable turbo.json just means no package gets extra
+	// entrypoints beyond "main"; it shouldn't block change detection.
+	packageEntrypoints, _ := loadPackageEntrypoints(repoRoot)
+
+	changedPackages := make(util.Set)
+	for pkgName, pkgInfo := range packageInfos {
+		if pkgName == util.RootPkgName {
+			continue
+		}
+		var extra []string
+		if name, ok := pkgName.(string); ok {
+			extra = packageEntrypoints[name]
+		}
+		reachable, ok := buildImportMap(entrypointsFor(pkgInfo, extra), parsers)
+		if !ok {
+			// Couldn't prove reachability for this package; fall back to
+			// directory-containment so we don't miss a real change.
+			pkgPath := pkgInfo.Dir.ToStringDuringMigration()
+			for _, changedFile := range changedFiles {
+				if fileInPackage(changedFile, pkgPath) {
+					changedPackages.Add(pkgName)
+					break
+				}
+			}
+			continue
+		}
+		for file := range reachable {
+			if changedSet[file.(string)] {
+				changedPackages.Add(pkgName)
+				break
+			}
+		}
+	}
+	return changedPackages
+}
@@ -0,0 +1,113 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeParser struct {
+	ext     string
+	imports map[string][]string
+}
+
+func (f *fakeParser) canParse(path string) bool {
+	return len(path) >= len(f.ext) && path[len(path)-len(f.ext):] == f.ext
+}
+
+func (f *fakeParser) imports(path string) ([]string, bool) {
+	imports, ok := f.imports[path]
+	return imports, ok
+}
+
+func TestBuildImportMap_NoEntrypointsFallsBack(t *testing.T) {
+	reachable, ok := buildImportMap(nil, []sourceParser{&fakeParser{ext: ".go"}})
+	if ok {
+		t.Fatalf("expected ok=false when there are no entrypoints to seed from, got reachable=%v ok=%v", reachable, ok)
+	}
+}
+
+func TestBuildImportMap_WalksReachableFiles(t *testing.T) {
+	parser := &fakeParser{
+		ext: ".go",
+		imports: map[string][]string{
+			"a.go": {"b.go", "c.go"},
+			"b.go": {"c.go"},
+			"c.go": {},
+		},
+	}
+	reachable, ok := buildImportMap([]string{"a.go"}, []sourceParser{parser})
+	if !ok {
+		t.Fatalf("expected ok=true, got false")
+	}
+	for _, file := range []string{"a.go", "b.go", "c.go"} {
+		if _, found := reachable[file]; !found {
+			t.Errorf("expected %v to be reachable, reachable set was %v", file, reachable)
+		}
+	}
+}
+
+func TestBuildImportMap_UnparsableFileFallsBack(t *testing.T) {
+	parser := &fakeParser{ext: ".go", imports: map[string][]string{}}
+	_, ok := buildImportMap([]string{"a.go"}, []sourceParser{parser})
+	if ok {
+		t.Fatalf("expected ok=false when a reachable file can't be parsed")
+	}
+}
+
+func TestJSSourceParser_ResolvesRelativeImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.js"), []byte(`import {b} from "./b"; const c = require('./c.js');`), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.js"), []byte(`export const b = 1;`), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.js"), []byte(`module.exports = 1;`), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+
+	p := &jsSourceParser{repoRoot: dir}
+	resolved, ok := p.imports(filepath.Join(dir, "a.js"))
+	if !ok {
+		t.Fatalf("expected ok=true, got false")
+	}
+	want := []string{filepath.Join(dir, "b.js"), filepath.Join(dir, "c.js")}
+	if len(resolved) != len(want) {
+		t.Fatalf("resolved = %v, want %v", resolved, want)
+	}
+	for i, w := range want {
+		if resolved[i] != w {
+			t.Errorf("resolved[%d] = %v, want %v", i, resolved[i], w)
+		}
+	}
+}
+
+func TestJSSourceParser_SkipsBareSpecifiers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.js"), []byte(`import react from "react";`), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+
+	p := &jsSourceParser{repoRoot: dir}
+	resolved, ok := p.imports(filepath.Join(dir, "a.js"))
+	if !ok {
+		t.Fatalf("expected ok=true, got false")
+	}
+	if len(resolved) != 0 {
+		t.Errorf("expected bare specifier to be skipped, got %v", resolved)
+	}
+}
+
+func TestJSSourceParser_UnresolvableRelativeImportFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.js"), []byte(`import {b} from "./missing";`), 0644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+
+	p := &jsSourceParser{repoRoot: dir}
+	_, ok := p.imports(filepath.Join(dir, "a.js"))
+	if ok {
+		t.Fatalf("expected ok=false when a relative import can't be resolved on disk")
+	}
+}
@@ -48,12 +48,50 @@ type Opts struct {
 	LegacyFilter LegacyFilter
 	// IgnorePatterns is the list of globs of file paths to ignore from execution scope calculation
 	IgnorePatterns []string
-	// GlobalDepPatterns is a list of globs to global files whose contents will be included in the global hash calculation
+	// GlobalDepPatterns is a list of globs to global files whose contents will be included in the global hash calculation.
+	// Each entry may optionally be suffixed with "=>scope" to classify how a match invalidates packages: "all" (the
+	// default, invalidates everything), "consumers:<pkg-glob>" (only packages matching the glob), or "type:<key>"
+	// (only packages listed under <key> in GlobalDepCategories).
 	GlobalDepPatterns []string
+	// GlobalDepCategories maps a "type:<key>" category name, as declared in turbo.json's
+	// "globalDependencyCategories" field, to the package names that depend on that category
+	// of global file. Left nil, it's lazily populated from turbo.json the first time a
+	// "type:<key>" rule needs it (see affectedPackagesForGlobalDeps); callers that already
+	// know the categories (e.g. tests) may set this directly to skip that lookup.
+	GlobalDepCategories map[string][]string
 	// Patterns are the filter patterns supplied to --filter on the commandline
 	FilterPatterns []string
 
 	PackageInferenceRoot string
+
+	// UseImportGraphForChanges enables source-aware change detection: instead of
+	// treating every file under a package's directory as affecting that package,
+	// a changed file only marks a package as changed if it's transitively
+	// imported from one of that package's entrypoints. Entrypoints come from
+	// package.json's "main" field plus any paths configured in turbo.json's
+	// "packageEntrypoints" (see loadPackageEntrypoints in import_graph.go).
+	// .go imports are resolved via go/parser; JS/TS imports are resolved via a
+	// regex-based scan that only follows relative ("./...") specifiers — bare
+	// specifiers (workspace packages, third-party deps) aren't file-level
+	// edges and are left to package-level dependency resolution. A package
+	// whose entrypoints (or anything reachable from them) can't be resolved
+	// falls back to the directory-containment behavior.
+	UseImportGraphForChanges bool
+
+	// SinceHashBaseline is the path to a content-hash baseline manifest
+	// (written by `turbo scope baseline write`). When set, changed packages
+	// are computed by comparing each package's current content hash against
+	// the baseline instead of diffing git refs, which works even when a
+	// reliable merge-base isn't available (e.g. shallow checkouts, release
+	// artifacts).
+	SinceHashBaseline string
+
+	// ChangeSource selects how changed files and fingerprints are determined:
+	// "git" (the default) diffs git refs via scm.SCM, "mtime" fingerprints
+	// files by modification time and size instead of relying on git history,
+	// and "workspace" (selected with --change-source=workspace) treats every
+	// uncommitted tracked file as changed regardless of which ref was requested.
+	ChangeSource string
 }
 
 var (
@@ -73,12 +111,22 @@ func AddFlags(opts *Opts, flags *pflag.FlagSet) {
 	flags.StringArrayVar(&opts.IgnorePatterns, "ignore", nil, _ignoreHelp)
 	flags.StringArrayVar(&opts.GlobalDepPatterns, "global-deps", nil, _globalDepHelp)
 	flags.StringVar(&opts.PackageInferenceRoot, "infer-filter-root", "", "Use the given monorepo-relative path as the basis for inferring tasks")
+	flags.BoolVar(&opts.UseImportGraphForChanges, "source-aware-changes", false, "Only mark a package as changed when a changed file is reachable from its entrypoints, instead of anywhere in its directory. Resolves .go imports and relative (\"./...\") JS/TS imports; bare JS/TS specifiers are skipped, and a package with any unresolvable import falls back to directory-containment.")
+	flags.StringVar(&opts.SinceHashBaseline, "since-hash", "", "Compute changed packages from a content-hash baseline manifest at the given path instead of diffing git refs.")
+	flags.Lookup("since-hash").NoOptDefVal = DefaultBaselinePath
+	flags.StringVar(&opts.ChangeSource, "change-source", "git", "Select how changed files are determined: \"git\" diffs refs, \"mtime\" fingerprints files by modification time, and \"workspace\" treats every uncommitted tracked file as changed.")
 	addLegacyFlags(&opts.LegacyFilter, flags)
 }
 
-// asFilterPatterns normalizes legacy selectors to filter syntax
-func (l *LegacyFilter) asFilterPatterns() []string {
+// asFilterPatterns normalizes legacy selectors to filter syntax. It also
+// returns the per-pattern ExpansionPolicy for any --scope entry that carried
+// a "{depth:N}"/"{tests-only}"/"{stop-at:...}" token, keyed by the (token-
+// stripped) package pattern: entries with a policy are emitted WITHOUT the
+// "..." dependents prefix, since the caller (ResolvePackages) is responsible
+// for expanding their dependents itself according to that policy.
+func (l *LegacyFilter) asFilterPatterns() ([]string, map[string]ExpansionPolicy) {
 	var patterns []string
+	policies := map[string]ExpansionPolicy{}
 	prefix := ""
 	if !l.SkipDependents {
 		prefix = "..."
@@ -96,20 +144,35 @@ func (l *LegacyFilter) asFilterPatterns() []string {
 		if since != "" {
 			since = "..." + since
 		}
-		for _, pattern := range l.Entrypoints {
-			if strings.HasPrefix(pattern, "!") {
-				patterns = append(patterns, pattern)
-			} else {
-				filterPattern := fmt.Sprintf("%v%v%v%v", prefix, pattern, since, suffix)
-				patterns = append(patterns, filterPattern)
+		for _, rawPattern := range l.Entrypoints {
+			if strings.HasPrefix(rawPattern, "!") {
+				patterns = append(patterns, rawPattern)
+				continue
 			}
+			pattern, policy, _ := ParseExpansionPolicy(rawPattern)
+			entryPrefix := prefix
+			if hasExpansionPolicy(policy) {
+				// We'll expand this pattern's dependents ourselves, governed
+				// by its policy, instead of letting the resolver's unlimited
+				// "..." walk do it.
+				entryPrefix = ""
+				policies[pattern] = policy
+			}
+			filterPattern := fmt.Sprintf("%v%v%v%v", entryPrefix, pattern, since, suffix)
+			patterns = append(patterns, filterPattern)
 		}
 	} else if since != "" {
 		// no scopes specified, but --since was provided
 		filterPattern := fmt.Sprintf("%v%v%v", prefix, since, suffix)
 		patterns = append(patterns, filterPattern)
 	}
-	return patterns
+	return patterns, policies
+}
+
+// hasExpansionPolicy reports whether policy differs from the zero value
+// (unlimited, unrestricted expansion).
+func hasExpansionPolicy(policy ExpansionPolicy) bool {
+	return policy.MaxDepth != 0 || policy.TestsOnly || len(policy.StopAtGlobs) > 0
 }
 
 // ResolvePackages translates specified flags to a set of entry point packages for
@@ -120,22 +183,33 @@ func ResolvePackages(opts *Opts, cwd string, scm scm.SCM, ctx *context.Context,
 	if err != nil {
 		return nil, false, err
 	}
+	filterPatterns := opts.FilterPatterns
+	legacyFilterPatterns, expansionPolicies := opts.LegacyFilter.asFilterPatterns()
+	filterPatterns = append(filterPatterns, legacyFilterPatterns...)
+
+	packagesChangedInRange := opts.getPackageChangeFunc(scm, cwd, ctx.PackageInfos)
+	if opts.SinceHashBaseline != "" {
+		packagesChangedInRange = opts.getPackageChangeFuncFromHash(cwd, ctx.PackageInfos)
+	}
 	filterResolver := &scope_filter.Resolver{
 		Graph:                  &ctx.TopologicalGraph,
 		PackageInfos:           ctx.PackageInfos,
 		Cwd:                    cwd,
 		Inference:              inferenceBase,
-		PackagesChangedInRange: opts.getPackageChangeFunc(scm, cwd, ctx.PackageInfos),
+		PackagesChangedInRange: packagesChangedInRange,
 	}
-	filterPatterns := opts.FilterPatterns
-	legacyFilterPatterns := opts.LegacyFilter.asFilterPatterns()
-	filterPatterns = append(filterPatterns, legacyFilterPatterns...)
 	isAllPackages := len(filterPatterns) == 0 && opts.PackageInferenceRoot == ""
 	filteredPkgs, err := filterResolver.GetPackagesFromPatterns(filterPatterns)
 	if err != nil {
 		return nil, false, err
 	}
 
+	if len(expansionPolicies) > 0 {
+		if err := expandPolicyDependents(cwd, ctx.PackageInfos, ctx.PackageNames, expansionPolicies, filteredPkgs); err != nil {
+			return nil, false, err
+		}
+	}
+
 	if isAllPackages {
 		// no filters specified, run every package
 		for _, f := range ctx.PackageNames {
@@ -146,6 +220,47 @@ func ResolvePackages(opts *Opts, cwd string, scm scm.SCM, ctx *context.Context,
 	return filteredPkgs, isAllPackages, nil
 }
 
+// expandPolicyDependents applies each pattern's ExpansionPolicy: it finds the
+// packages matching that pattern that the filters already selected (so --since
+// and "!" exclusions still apply), walks their dependents according to the
+// policy (using a reverse-dependency index built from packageInfos, see
+// reverseDependencyIndex), and adds the result into filteredPkgs in place.
+func expandPolicyDependents(cwd string, packageInfos map[interface{}]*fs.PackageJSON, packageNames []interface{}, policies map[string]ExpansionPolicy, filteredPkgs util.Set) error {
+	index, err := reverseDependencyIndex(cwd, packageInfos)
+	if err != nil {
+		return err
+	}
+	dependentsOf := func(pkg interface{}) []interface{} { return index[pkg] }
+	isTestConsumer := func(pkg interface{}) bool {
+		info, ok := packageInfos[pkg]
+		return ok && info.Scripts["test"] != ""
+	}
+
+	for pattern, policy := range policies {
+		patternGlob, err := filter.Compile([]string{pattern})
+		if err != nil {
+			return errors.Wrapf(err, "invalid --scope pattern %q", pattern)
+		}
+		matched := make(util.Set)
+		for _, name := range packageNames {
+			if _, selected := filteredPkgs[name]; !selected {
+				continue
+			}
+			if patternGlob == nil || patternGlob.Match(name.(string)) {
+				matched.Add(name)
+			}
+		}
+		expanded, err := ExpandDependents(matched, policy, dependentsOf, isTestConsumer)
+		if err != nil {
+			return err
+		}
+		for pkg := range expanded {
+			filteredPkgs.Add(pkg)
+		}
+	}
+	return nil
+}
+
 func calculateInference(rawRepoRoot string, rawPkgInferenceDir string, packageInfos map[interface{}]*fs.PackageJSON) (*scope_filter.PackageInference, error) {
 	if rawPkgInferenceDir == "" {
 		// No inference specified, no need to calculate anything
@@ -183,52 +298,43 @@ func calculateInference(rawRepoRoot string, rawPkgInferenceDir string, packageIn
 	}, nil
 }
 
-func (o *Opts) getPackageChangeFunc(scm scm.SCM, cwd string, packageInfos map[interface{}]*fs.PackageJSON) scope_filter.PackagesChangedInRange {
+func (o *Opts) getPackageChangeFunc(gitSCM scm.SCM, cwd string, packageInfos map[interface{}]*fs.PackageJSON) scope_filter.PackagesChangedInRange {
 	return func(fromRef string, toRef string) (util.Set, error) {
+		changeSource, err := newChangeSource(o.ChangeSource, gitSCM, cwd, cwd)
+		if err != nil {
+			return nil, err
+		}
 		// We could filter changed files at the git level, since it's possible
 		// that the changes we're interested in are scoped, but we need to handle
 		// global dependencies changing as well. A future optimization might be to
 		// scope changed files more deeply if we know there are no global dependencies.
 		var changedFiles []string
-		if fromRef != "" {
-			scmChangedFiles, err := scm.ChangedFiles(fromRef, toRef, true, cwd)
+		if fromRef != "" || o.ChangeSource == "mtime" || o.ChangeSource == "workspace" {
+			scmChangedFiles, err := changeSource.ChangedFiles(fromRef, toRef)
 			if err != nil {
 				return nil, err
 			}
 			changedFiles = scmChangedFiles
 		}
-		if hasRepoGlobalFileChanged, err := repoGlobalFileHasChanged(o, changedFiles); err != nil {
+		globalAffectedPkgs, err := affectedPackagesForGlobalDeps(o, cwd, changedFiles, packageInfos)
+		if err != nil {
 			return nil, err
-		} else if hasRepoGlobalFileChanged {
-			allPkgs := make(util.Set)
-			for pkg := range packageInfos {
-				allPkgs.Add(pkg)
-			}
-			return allPkgs, nil
 		}
 		filteredChangedFiles, err := filterIgnoredFiles(o, changedFiles)
 		if err != nil {
 			return nil, err
 		}
-		changedPkgs := getChangedPackages(filteredChangedFiles, packageInfos)
-		return changedPkgs, nil
-	}
-}
-
-func repoGlobalFileHasChanged(opts *Opts, changedFiles []string) (bool, error) {
-	globalDepsGlob, err := filter.Compile(opts.GlobalDepPatterns)
-	if err != nil {
-		return false, errors.Wrap(err, "invalid global deps glob")
-	}
-
-	if globalDepsGlob != nil {
-		for _, file := range changedFiles {
-			if globalDepsGlob.Match(filepath.ToSlash(file)) {
-				return true, nil
-			}
+		var changedPkgs util.Set
+		if o.UseImportGraphForChanges {
+			changedPkgs = getChangedPackagesSourceAware(cwd, filteredChangedFiles, packageInfos)
+		} else {
+			changedPkgs = getChangedPackages(filteredChangedFiles, packageInfos)
 		}
+		for pkg := range globalAffectedPkgs {
+			changedPkgs.Add(pkg)
+		}
+		return changedPkgs, nil
 	}
-	return false, nil
 }
 
 func filterIgnoredFiles(opts *Opts, changedFiles []string) ([]string, error) {